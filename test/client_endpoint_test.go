@@ -19,19 +19,31 @@ package test
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"io/ioutil"
+	"math/big"
 	"net/url"
 	"os"
 	"path"
 	"runtime"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
 
 	"github.com/networkservicemesh/api/pkg/api/networkservice"
 	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/cls"
@@ -42,11 +54,16 @@ import (
 	"github.com/networkservicemesh/sdk/pkg/networkservice/common/authorize"
 	"github.com/networkservicemesh/sdk/pkg/networkservice/common/connect"
 	"github.com/networkservicemesh/sdk/pkg/networkservice/common/discover"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/common/filtermechanisms"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/common/heal"
 	"github.com/networkservicemesh/sdk/pkg/networkservice/common/roundrobin"
 	"github.com/networkservicemesh/sdk/pkg/networkservice/common/setextracontext"
 	registryclient "github.com/networkservicemesh/sdk/pkg/registry/chains/client"
+	registryauthorize "github.com/networkservicemesh/sdk/pkg/registry/common/authorize"
+	"github.com/networkservicemesh/sdk/pkg/registry/common/localbypass"
 	"github.com/networkservicemesh/sdk/pkg/registry/common/recvfd"
 	"github.com/networkservicemesh/sdk/pkg/registry/core/chain"
+	"github.com/networkservicemesh/sdk/pkg/registry/memory"
 	"github.com/networkservicemesh/sdk/pkg/tools/clienturlctx"
 	"github.com/networkservicemesh/sdk/pkg/tools/grpcutils"
 	"github.com/networkservicemesh/sdk/pkg/tools/spiffejwt"
@@ -68,6 +85,7 @@ type myEndpouint struct {
 func newCrossNSE(ctx context.Context, name string, connectTo *url.URL, tokenGenerator token.GeneratorFunc, clientDialOptions ...grpc.DialOption) endpoint.Endpoint {
 	var crossNSe = &myEndpouint{}
 	nseClient := chain.NewNetworkServiceEndpointRegistryClient(
+		localbypass.NewNetworkServiceEndpointRegistryClient(),
 		registryclient.NewNetworkServiceEndpointRegistryClient(ctx, connectTo,
 			registryclient.WithNSEAdditionalFunctionality(recvfd.NewNetworkServiceEndpointRegistryClient()),
 			registryclient.WithDialOptions(clientDialOptions...),
@@ -80,8 +98,10 @@ func newCrossNSE(ctx context.Context, name string, connectTo *url.URL, tokenGene
 		endpoint.WithAuthorizeServer(authorize.NewServer()),
 		// Statically set the url we use to the unix file socket for the NSMgr
 		endpoint.WithAdditionalFunctionality(
+			filtermechanisms.NewServer(nseClient),
 			discover.NewServer(nsClient, nseClient),
 			roundrobin.NewServer(),
+			heal.NewServer(ctx, heal.WithNSMgrURL(connectTo)),
 			connect.NewServer(
 				client.NewClient(
 					ctx,
@@ -145,7 +165,7 @@ func (f *NsmgrTestSuite) TestNSmgrEndpointSendFD() {
 
 	logrus.Infof("Register cross NSE")
 
-	f.registerCrossNSE(ctx, setup, nseRegClient, t)
+	f.registerCrossNSE(ctx, setup, nseRegClient, t, "unix")
 
 	cl := client.NewClient(context.Background(),
 		client.WithName("nsc-1"),
@@ -174,11 +194,516 @@ func (f *NsmgrTestSuite) TestNSmgrEndpointSendFD() {
 	require.Nil(t, err)
 }
 
-func (f *NsmgrTestSuite) registerCrossNSE(ctx context.Context, setup *testSetup, regClient registry.NetworkServiceEndpointRegistryClient, t *testing.T) {
-	// Serve Cross Connect NSE
-	crossNSEURL := &url.URL{Scheme: "tcp", Host: "127.0.0.1:0"}
+// TestNSmgrHeal checks that a Connection is healed onto a live NSE when the
+// NSE it was originally connected to goes away, without the client having to
+// re-issue a Request.
+func (f *NsmgrTestSuite) TestNSmgrHeal() {
+	if runtime.GOOS != "linux" {
+		f.T().Skip("not a linux")
+	}
+	t := f.T()
+	setup := newSetup(t)
+	setup.Start()
+	defer setup.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	rootDir, _ := ioutil.TempDir(os.TempDir(), "nsmgr")
+
+	nse1URL := &url.URL{Scheme: "unix", Path: path.Join(rootDir, "nse-1.socket")}
+	nse2URL := &url.URL{Scheme: "unix", Path: path.Join(rootDir, "nse-2.socket")}
+
+	_, nse1GRPC := serve(ctx, nse1URL,
+		endpoint.NewServer(ctx,
+			spiffejwt.TokenGeneratorFunc(setup.Source, setup.configuration.MaxTokenLifetime),
+			endpoint.WithName("nse-1"),
+			endpoint.WithAuthorizeServer(authorize.NewServer()),
+			endpoint.WithAdditionalFunctionality(
+				setextracontext.NewServer(map[string]string{"perform": "ok"}))),
+		grpc.Creds(credentials.NewTLS(tlsconfig.MTLSServerConfig(setup.Source, setup.Source, tlsconfig.AuthorizeAny()))),
+	)
+	_, nse2GRPC := serve(ctx, nse2URL,
+		endpoint.NewServer(ctx,
+			spiffejwt.TokenGeneratorFunc(setup.Source, setup.configuration.MaxTokenLifetime),
+			endpoint.WithName("nse-2"),
+			endpoint.WithAuthorizeServer(authorize.NewServer()),
+			endpoint.WithAdditionalFunctionality(
+				setextracontext.NewServer(map[string]string{"perform": "ok"}))),
+		grpc.Creds(credentials.NewTLS(tlsconfig.MTLSServerConfig(setup.Source, setup.Source, tlsconfig.AuthorizeAny()))),
+	)
+	require.NotNil(t, nse1GRPC)
+	require.NotNil(t, nse2GRPC)
+
+	nsRegClient := registryclient.NewNetworkServiceRegistryClient(ctx, &setup.configuration.ListenOn[0], registryclient.WithDialOptions(setup.dialOptions()...))
+	nseRegClient := registryclient.NewNetworkServiceEndpointRegistryClient(ctx, &setup.configuration.ListenOn[0], registryclient.WithDialOptions(setup.dialOptions()...))
+
+	ns, nserr := nsRegClient.Register(context.Background(), &registry.NetworkService{
+		Name: "my-service",
+	})
+	require.NoError(t, nserr)
+
+	_, err := nseRegClient.Register(context.Background(), &registry.NetworkServiceEndpoint{
+		Name:                "nse-1",
+		NetworkServiceNames: []string{ns.Name},
+		Url:                 nse1URL.String(),
+	})
+	require.Nil(t, err)
+
+	_, err = nseRegClient.Register(context.Background(), &registry.NetworkServiceEndpoint{
+		Name:                "nse-2",
+		NetworkServiceNames: []string{ns.Name},
+		Url:                 nse2URL.String(),
+	})
+	require.Nil(t, err)
+
+	f.registerCrossNSE(ctx, setup, nseRegClient, t, "unix")
+
+	cl := client.NewClient(context.Background(),
+		client.WithName("nsc-1"),
+		client.WithDialTimeout(5*time.Second),
+		client.WithDialOptions(setup.dialOptions()...),
+	)
+
+	ctx = clienturlctx.WithClientURL(ctx, &setup.configuration.ListenOn[0])
+
+	connection, err := cl.Request(ctx, &networkservice.NetworkServiceRequest{
+		MechanismPreferences: []*networkservice.Mechanism{
+			{Cls: cls.LOCAL, Type: kernel.MECHANISM},
+		},
+		Connection: &networkservice.Connection{
+			Id:             "1",
+			NetworkService: "my-service",
+			Context:        &networkservice.ConnectionContext{},
+		},
+	})
+	require.Nil(t, err)
+	require.NotNil(t, connection)
+
+	firstNSEName := connection.Path.PathSegments[len(connection.Path.PathSegments)-1].Name
+	require.Contains(t, []string{"nse-1", "nse-2"}, firstNSEName)
+
+	// Kill the NSE the Connection currently lives on - heal should pick a
+	// live one instead, without the client re-issuing a Request.
+	if firstNSEName == "nse-1" {
+		nse1GRPC.Stop()
+	} else {
+		nse2GRPC.Stop()
+	}
+
+	nsmgrConn, dialErr := grpc.DialContext(ctx, setup.configuration.ListenOn[0].String(), setup.dialOptions()...)
+	require.Nil(t, dialErr)
+	defer func() { _ = nsmgrConn.Close() }()
+
+	monitorClient := networkservice.NewMonitorConnectionClient(nsmgrConn)
+	stream, monitorErr := monitorClient.MonitorConnections(ctx, &networkservice.MonitorScopeSelector{
+		PathSegments: []*networkservice.PathSegment{{Id: connection.Id}},
+	})
+	require.Nil(t, monitorErr)
+
+	require.Eventually(t, func() bool {
+		event, recvErr := stream.Recv()
+		if recvErr != nil {
+			return false
+		}
+		healed, ok := event.Connections[connection.Id]
+		if !ok {
+			return false
+		}
+		name := healed.Path.PathSegments[len(healed.Path.PathSegments)-1].Name
+		if name == firstNSEName {
+			return false
+		}
+		connection = healed
+		return true
+	}, 10*time.Second, 100*time.Millisecond)
+
+	_, err = cl.Close(ctx, connection)
+	require.Nil(t, err)
+}
+
+// TestNSmgrEndpointSendFD_NoReachableMechanism checks that a client asking for
+// a LOCAL mechanism gets a clean error - rather than an opaque forwarder
+// failure - when the only registered forwarder can only reach clients
+// remotely.
+func (f *NsmgrTestSuite) TestNSmgrEndpointSendFD_NoReachableMechanism() {
+	if runtime.GOOS != "linux" {
+		f.T().Skip("not a linux")
+	}
+	t := f.T()
+	setup := newSetup(t)
+	setup.Start()
+	defer setup.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	rootDir, _ := ioutil.TempDir(os.TempDir(), "nsmgr")
+
+	nseURL := &url.URL{Scheme: "unix", Path: path.Join(rootDir, "endpoint.socket")}
+
+	_, nseGRPC := serve(ctx, nseURL,
+		endpoint.NewServer(ctx,
+			spiffejwt.TokenGeneratorFunc(setup.Source, setup.configuration.MaxTokenLifetime),
+			endpoint.WithName("nse"),
+			endpoint.WithAuthorizeServer(authorize.NewServer()),
+			endpoint.WithAdditionalFunctionality(
+				setextracontext.NewServer(map[string]string{"perform": "ok"}))),
+		grpc.Creds(credentials.NewTLS(tlsconfig.MTLSServerConfig(setup.Source, setup.Source, tlsconfig.AuthorizeAny()))),
+	)
+	require.NotNil(t, nseGRPC)
+
+	nsRegClient := registryclient.NewNetworkServiceRegistryClient(ctx, &setup.configuration.ListenOn[0], registryclient.WithDialOptions(setup.dialOptions()...))
+	nseRegClient := registryclient.NewNetworkServiceEndpointRegistryClient(ctx, &setup.configuration.ListenOn[0], registryclient.WithDialOptions(setup.dialOptions()...))
+
+	ns, nserr := nsRegClient.Register(context.Background(), &registry.NetworkService{
+		Name: "my-service",
+	})
+	require.NoError(t, nserr)
+
+	_, err := nseRegClient.Register(context.Background(), &registry.NetworkServiceEndpoint{
+		Name:                "nse-1",
+		NetworkServiceNames: []string{ns.Name},
+		Url:                 nseURL.String(),
+	})
+	require.Nil(t, err)
+
+	// Request a tcp:// (remote-capable-only) cross-nse - unlike the rest of
+	// the suite's unix:// forwarders, no LOCAL mechanism is reachable
+	// through it, so this exercises the filtermechanisms wiring added to
+	// newCrossNSE.
+	f.registerCrossNSE(ctx, setup, nseRegClient, t, "tcp")
+
+	cl := client.NewClient(context.Background(),
+		client.WithName("nsc-1"),
+		client.WithDialTimeout(5*time.Second),
+		client.WithDialOptions(setup.dialOptions()...),
+	)
+
+	ctx = clienturlctx.WithClientURL(ctx, &setup.configuration.ListenOn[0])
+
+	_, err = cl.Request(ctx, &networkservice.NetworkServiceRequest{
+		MechanismPreferences: []*networkservice.Mechanism{
+			{Cls: cls.LOCAL, Type: kernel.MECHANISM},
+		},
+		Connection: &networkservice.Connection{
+			Id:             "1",
+			NetworkService: "my-service",
+			Context:        &networkservice.ConnectionContext{},
+		},
+	})
+	require.NotNil(t, err)
+
+	// filtermechanisms must reject the request with a clean, descriptive
+	// error before any forwarder dial is attempted - not an opaque
+	// Unavailable/DeadlineExceeded transport failure.
+	code := status.Code(err)
+	require.NotEqual(t, codes.Unavailable, code)
+	require.NotEqual(t, codes.DeadlineExceeded, code)
+	require.Contains(t, err.Error(), "mechanism")
+}
+
+// staticX509Source is a minimal x509svid.Source/x509bundle.Source used to
+// hand out hand-rolled SPIFFE identities in TestRegistryAuthorizeOwnership,
+// without depending on a running SPIRE deployment.
+type staticX509Source struct {
+	svid   *x509svid.SVID
+	bundle *x509bundle.Bundle
+}
+
+func (s *staticX509Source) GetX509SVID() (*x509svid.SVID, error) {
+	return s.svid, nil
+}
+
+func (s *staticX509Source) GetX509BundleForTrustDomain(_ spiffeid.TrustDomain) (*x509bundle.Bundle, error) {
+	return s.bundle, nil
+}
+
+func newTestCA(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func newTestIdentity(t *testing.T, serial int64, caCert *x509.Certificate, caKey *ecdsa.PrivateKey, id spiffeid.ID) *staticX509Source {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		URIs:         []*url.URL{id.URL()},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &staticX509Source{
+		svid: &x509svid.SVID{
+			ID:           id,
+			Certificates: []*x509.Certificate{cert},
+			PrivateKey:   key,
+		},
+		bundle: x509bundle.FromX509Authorities(id.TrustDomain(), []*x509.Certificate{caCert}),
+	}
+}
+
+// TestNSmgrEndpointSendFD_LocalBypass checks that once the cross-NSE resolves
+// the real NSE it needs to talk to, it dials that NSE's socket directly
+// instead of looping the data-plane Request back through the NSMgr.
+func (f *NsmgrTestSuite) TestNSmgrEndpointSendFD_LocalBypass() {
+	if runtime.GOOS != "linux" {
+		f.T().Skip("not a linux")
+	}
+	t := f.T()
+	setup := newSetup(t)
+	setup.Start()
+	defer setup.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	rootDir, _ := ioutil.TempDir(os.TempDir(), "nsmgr")
+
+	nseURL := &url.URL{Scheme: "unix", Path: path.Join(rootDir, "endpoint.socket")}
+
+	_, nseGRPC := serve(ctx, nseURL,
+		endpoint.NewServer(ctx,
+			spiffejwt.TokenGeneratorFunc(setup.Source, setup.configuration.MaxTokenLifetime),
+			endpoint.WithName("nse"),
+			endpoint.WithAuthorizeServer(authorize.NewServer()),
+			endpoint.WithAdditionalFunctionality(
+				setextracontext.NewServer(map[string]string{"perform": "ok"}))),
+		grpc.Creds(credentials.NewTLS(tlsconfig.MTLSServerConfig(setup.Source, setup.Source, tlsconfig.AuthorizeAny()))),
+	)
+	require.NotNil(t, nseGRPC)
+
+	nsRegClient := registryclient.NewNetworkServiceRegistryClient(ctx, &setup.configuration.ListenOn[0], registryclient.WithDialOptions(setup.dialOptions()...))
+	nseRegClient := registryclient.NewNetworkServiceEndpointRegistryClient(ctx, &setup.configuration.ListenOn[0], registryclient.WithDialOptions(setup.dialOptions()...))
+
+	ns, nserr := nsRegClient.Register(context.Background(), &registry.NetworkService{
+		Name: "my-service",
+	})
+	require.NoError(t, nserr)
+
+	_, err := nseRegClient.Register(context.Background(), &registry.NetworkServiceEndpoint{
+		Name:                "nse-1",
+		NetworkServiceNames: []string{ns.Name},
+		Url:                 nseURL.String(),
+	})
+	require.Nil(t, err)
+
+	nsmgrTarget := setup.configuration.ListenOn[0].String()
+	var dataPlaneCallsToNSMgr int32
+	countingInterceptor := func(
+		ctx context.Context, method string, req, reply interface{},
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+	) error {
+		if cc.Target() == nsmgrTarget {
+			atomic.AddInt32(&dataPlaneCallsToNSMgr, 1)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	f.registerCrossNSE(ctx, setup, nseRegClient, t, "unix", grpc.WithChainUnaryInterceptor(countingInterceptor))
+
+	cl := client.NewClient(context.Background(),
+		client.WithName("nsc-1"),
+		client.WithDialTimeout(5*time.Second),
+		client.WithDialOptions(setup.dialOptions()...),
+	)
+
+	ctx = clienturlctx.WithClientURL(ctx, &setup.configuration.ListenOn[0])
+
+	connection, err := cl.Request(ctx, &networkservice.NetworkServiceRequest{
+		MechanismPreferences: []*networkservice.Mechanism{
+			{Cls: cls.LOCAL, Type: kernel.MECHANISM},
+		},
+		Connection: &networkservice.Connection{
+			Id:             "1",
+			NetworkService: "my-service",
+			Context:        &networkservice.ConnectionContext{},
+		},
+	})
+	require.Nil(t, err)
+	require.NotNil(t, connection)
+	require.Equal(t, int32(0), atomic.LoadInt32(&dataPlaneCallsToNSMgr))
+
+	_, err = cl.Close(ctx, connection)
+	require.Nil(t, err)
+}
+
+// TestRegistryAuthorizeOwnership checks that the registry authorize chain
+// element rejects a spiffeID trying to re-register (hijack) an NSE name
+// already owned by a different spiffeID.
+func (f *NsmgrTestSuite) TestRegistryAuthorizeOwnership() {
+	t := f.T()
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	caCert, caKey := newTestCA(t)
+	trustDomain := spiffeid.RequireTrustDomainFromString("example.org")
+	serverSource := newTestIdentity(t, 2, caCert, caKey, spiffeid.RequireFromPath(trustDomain, "/registry"))
+	owner1Source := newTestIdentity(t, 3, caCert, caKey, spiffeid.RequireFromPath(trustDomain, "/owner-1"))
+	owner2Source := newTestIdentity(t, 4, caCert, caKey, spiffeid.RequireFromPath(trustDomain, "/owner-2"))
+
+	policy, err := ioutil.ReadFile("policies/registry_ownership.rego")
+	require.NoError(t, err)
+
+	nseRegistryServer := chain.NewNetworkServiceEndpointRegistryServer(
+		registryauthorize.NewNetworkServiceEndpointRegistryServer(registryauthorize.WithPolicies(string(policy))),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	)
+
+	regURL := &url.URL{Scheme: "tcp", Host: "127.0.0.1:0"}
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(tlsconfig.MTLSServerConfig(serverSource, serverSource, tlsconfig.AuthorizeAny()))))
+	registry.RegisterNetworkServiceEndpointRegistryServer(server, nseRegistryServer)
+	errCh := grpcutils.ListenAndServe(ctx, regURL, server)
+	require.NotNil(t, errCh)
+
+	dial := func(source *staticX509Source) registry.NetworkServiceEndpointRegistryClient {
+		return registryclient.NewNetworkServiceEndpointRegistryClient(ctx, regURL,
+			registryclient.WithDialOptions(
+				grpc.WithTransportCredentials(credentials.NewTLS(tlsconfig.MTLSClientConfig(source, source, tlsconfig.AuthorizeAny()))),
+			),
+		)
+	}
+
+	_, err = dial(owner1Source).Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.Nil(t, err)
+
+	_, err = dial(owner2Source).Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.NotNil(t, err)
+}
+
+// TestNSmgrRegistryExpire checks that an NSE which stops sending keepalives
+// gets expired from the registry after NSM_DEFAULT_EXPIRATION elapses, and
+// that InitialRegistrationTime survives a re-registration before expiry.
+func (f *NsmgrTestSuite) TestNSmgrRegistryExpire() {
+	if runtime.GOOS != "linux" {
+		f.T().Skip("not a linux")
+	}
+	t := f.T()
+
+	const expiration = 2 * time.Second
+	require.NoError(t, os.Setenv("NSM_DEFAULT_EXPIRATION", expiration.String()))
+	defer func() { _ = os.Unsetenv("NSM_DEFAULT_EXPIRATION") }()
+
+	setup := newSetup(t)
+	setup.Start()
+	defer setup.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	rootDir, _ := ioutil.TempDir(os.TempDir(), "nsmgr")
+	nseURL := &url.URL{Scheme: "unix", Path: path.Join(rootDir, "endpoint.socket")}
+
+	_, nseGRPC := serve(ctx, nseURL,
+		endpoint.NewServer(ctx,
+			spiffejwt.TokenGeneratorFunc(setup.Source, setup.configuration.MaxTokenLifetime),
+			endpoint.WithName("nse"),
+			endpoint.WithAuthorizeServer(authorize.NewServer()),
+			endpoint.WithAdditionalFunctionality(
+				setextracontext.NewServer(map[string]string{"perform": "ok"}))),
+		grpc.Creds(credentials.NewTLS(tlsconfig.MTLSServerConfig(setup.Source, setup.Source, tlsconfig.AuthorizeAny()))),
+	)
+	require.NotNil(t, nseGRPC)
+
+	nsRegClient := registryclient.NewNetworkServiceRegistryClient(ctx, &setup.configuration.ListenOn[0], registryclient.WithDialOptions(setup.dialOptions()...))
+
+	// nse-1 is registered through its own cancellable context/client so its
+	// background keepalive refresh can actually be stopped - sharing a
+	// client with cross-nse would keep nse-1 refreshed for as long as
+	// cross-nse's registration needs to stay alive.
+	nse1Ctx, nse1Cancel := context.WithCancel(ctx)
+	nse1RegClient := registryclient.NewNetworkServiceEndpointRegistryClient(nse1Ctx, &setup.configuration.ListenOn[0], registryclient.WithDialOptions(setup.dialOptions()...))
+
+	ns, nserr := nsRegClient.Register(context.Background(), &registry.NetworkService{
+		Name: "my-service",
+	})
+	require.NoError(t, nserr)
+
+	nseReg := &registry.NetworkServiceEndpoint{
+		Name:                "nse-1",
+		NetworkServiceNames: []string{ns.Name},
+		Url:                 nseURL.String(),
+	}
+	nseReg, err := nse1RegClient.Register(nse1Ctx, nseReg)
+	require.Nil(t, err)
+	require.NotNil(t, nseReg.InitialRegistrationTime)
+	initialRegistrationTime := nseReg.InitialRegistrationTime
+
+	// Re-register before expiry - InitialRegistrationTime must be preserved,
+	// only LastUpdateTime should move forward.
+	nseReg, err = nse1RegClient.Register(nse1Ctx, nseReg)
+	require.Nil(t, err)
+	require.Equal(t, initialRegistrationTime, nseReg.InitialRegistrationTime)
+
+	// Stop sending keepalives for nse-1 by tearing down its dedicated
+	// registry client/context.
+	nse1Cancel()
+
+	// cross-nse is registered through its own, still-live client so only
+	// nse-1's expiry is being measured below.
+	crossRegClient := registryclient.NewNetworkServiceEndpointRegistryClient(ctx, &setup.configuration.ListenOn[0], registryclient.WithDialOptions(setup.dialOptions()...))
+	f.registerCrossNSE(ctx, setup, crossRegClient, t, "unix")
+
+	cl := client.NewClient(context.Background(),
+		client.WithName("nsc-1"),
+		client.WithDialTimeout(5*time.Second),
+		client.WithDialOptions(setup.dialOptions()...),
+	)
+	ctx = clienturlctx.WithClientURL(ctx, &setup.configuration.ListenOn[0])
+
+	// Wait for nse-1 to expire out of the registry, which should surface as
+	// a failed/failed-over Request.
+	require.Eventually(t, func() bool {
+		_, requestErr := cl.Request(ctx, &networkservice.NetworkServiceRequest{
+			MechanismPreferences: []*networkservice.Mechanism{
+				{Cls: cls.LOCAL, Type: kernel.MECHANISM},
+			},
+			Connection: &networkservice.Connection{
+				Id:             "1",
+				NetworkService: "my-service",
+				Context:        &networkservice.ConnectionContext{},
+			},
+		})
+		return requestErr != nil
+	}, expiration*5, 200*time.Millisecond)
+}
+
+func (f *NsmgrTestSuite) registerCrossNSE(ctx context.Context, setup *testSetup, regClient registry.NetworkServiceEndpointRegistryClient, t *testing.T, scheme string, extraDialOptions ...grpc.DialOption) {
+	// Serve Cross Connect NSE - the scheme determines which mechanisms
+	// filtermechanisms will consider reachable through it: unix:// for
+	// LOCAL, tcp:// for REMOTE only.
+	var crossNSEURL *url.URL
+	switch scheme {
+	case "unix":
+		rootDir, _ := ioutil.TempDir(os.TempDir(), "nsmgr-forwarder")
+		crossNSEURL = &url.URL{Scheme: "unix", Path: path.Join(rootDir, "cross-nse.socket")}
+	case "tcp":
+		crossNSEURL = &url.URL{Scheme: "tcp", Host: "127.0.0.1:0"}
+	default:
+		t.Fatalf("unsupported cross-nse scheme %q", scheme)
+	}
+	dialOptions := append(append([]grpc.DialOption{}, setup.dialOptions()...), extraDialOptions...)
 	endpoint.Serve(ctx, crossNSEURL,
-		newCrossNSE(ctx, "cross-nse", &setup.configuration.ListenOn[0], spiffejwt.TokenGeneratorFunc(setup.Source, setup.configuration.MaxTokenLifetime), setup.dialOptions()...),
+		newCrossNSE(ctx, "cross-nse", &setup.configuration.ListenOn[0], spiffejwt.TokenGeneratorFunc(setup.Source, setup.configuration.MaxTokenLifetime), dialOptions...),
 		grpc.Creds(credentials.NewTLS(tlsconfig.MTLSServerConfig(setup.Source, setup.Source, tlsconfig.AuthorizeAny()))))
 	logrus.Infof("Cross NSE listenON: %v", crossNSEURL.String())
 